@@ -24,6 +24,7 @@ import (
 	"github.com/elastic/elastic-agent-libs/mapstr"
 	"github.com/elastic/elastic-agent-libs/opt"
 	"github.com/elastic/elastic-agent-system-metrics/metric"
+	"github.com/elastic/elastic-agent-system-metrics/metric/system/pressure"
 )
 
 // CPU manages the CPU metrics from /proc/stat
@@ -40,6 +41,13 @@ type CPU struct {
 	Wait    opt.Uint `struct:"iowait,omitempty"`  // Linux and AIX
 	SoftIrq opt.Uint `struct:"softirq,omitempty"` // Linux only
 	Stolen  opt.Uint `struct:"steal,omitempty"`   // Linux only
+
+	// ThrottledPeriods and ThrottledTimeNs come from cgroup v2's cpu.stat
+	// (nr_throttled and throttled_usec, the latter converted to
+	// nanoseconds) and are only populated when the Monitor is scoped to a
+	// cgroup via WithCgroupV2Scope.
+	ThrottledPeriods opt.Uint `struct:"throttled_periods,omitempty"`
+	ThrottledTimeNs  opt.Uint `struct:"throttled_time_ns,omitempty"`
 }
 
 // MetricOpts defines the fields that are passed along to the formatted output
@@ -47,6 +55,10 @@ type MetricOpts struct {
 	Ticks                 bool
 	Percentages           bool
 	NormalizedPercentages bool
+	// Pressure adds the PSI (pressure stall information) fields to the
+	// formatted totals. It has no effect on platforms that don't support
+	// PSI; see package metric/system/pressure.
+	Pressure bool
 }
 
 // CPUInfo manages the CPU information from /proc/cpuinfo
@@ -58,6 +70,32 @@ type CPUInfo struct {
 	Mhz         float64
 	PhysicalID  int
 	CoreID      int
+
+	// CurMhz, MinMhz and MaxMhz carry the live scaling frequency reported
+	// by cpufreq. Unlike Mhz, which comes from /proc/cpuinfo and is
+	// effectively static, these reflect the core's current throttling or
+	// turbo state. Only Linux fills them in; on other platforms they are
+	// left at zero.
+	CurMhz float64
+	MinMhz float64
+	MaxMhz float64
+
+	// Governor is the active cpufreq scaling governor. Linux only.
+	Governor string
+
+	// CStates carries cumulative C-state residency, keyed by state name,
+	// as reported under
+	// /sys/devices/system/cpu/cpu*/cpuidle/state*/time. Linux only.
+	CStates map[string]uint64
+}
+
+// IsZero reports whether info carries no data at all, which happens on
+// platforms that don't populate any of it.
+func (info CPUInfo) IsZero() bool {
+	return info.ModelName == "" && info.ModelNumber == "" && info.Mhz == 0 &&
+		info.PhysicalID == 0 && info.CoreID == 0 &&
+		info.CurMhz == 0 && info.MinMhz == 0 && info.MaxMhz == 0 &&
+		info.Governor == "" && len(info.CStates) == 0
 }
 
 // CPUMetrics carries global and per-core CPU metrics
@@ -69,6 +107,10 @@ type CPUMetrics struct {
 
 	// CPUInfo carries some data from /proc/cpuinfo
 	CPUInfo []CPUInfo
+
+	// Pressure carries PSI data for the host or cgroup as a whole. It is
+	// only populated on Linux.
+	Pressure pressure.CPUPressure
 }
 
 // Total returns the total CPU time in ticks as scraped by the API
@@ -80,6 +122,7 @@ func (cpu CPU) Total() uint64 {
 
 type option struct {
 	usePerformanceCounter bool
+	cgroupV2Path          string
 }
 
 type OptionFunc func(*option)
@@ -91,6 +134,22 @@ func WithWindowsPerformanceCounter() OptionFunc {
 	}
 }
 
+// WithCgroupV2Scope scopes CPU accounting to the cgroup v2 hierarchy
+// rooted at path instead of the host-wide /proc/stat: Get reads
+// usage_usec, user_usec, system_usec, nr_throttled and throttled_usec from
+// path's cpu.stat, and derives the CPU count from cpu.max and
+// cpuset.cpus.effective instead of runtime.NumCPU(), which overreports
+// inside containers with a fractional or partial CPU quota.
+//
+// Note: like WithWindowsPerformanceCounter, this option is only effective
+// on the platform it targets (Linux) and is ineffective if used on other
+// OS'.
+func WithCgroupV2Scope(path string) OptionFunc {
+	return func(o *option) {
+		o.cgroupV2Path = path
+	}
+}
+
 // Fetch collects a new sample of the CPU usage metrics.
 // This will overwrite the currently stored samples.
 func (m *Monitor) Fetch() (Metrics, error) {
@@ -102,7 +161,13 @@ func (m *Monitor) Fetch() (Metrics, error) {
 	oldLastSample := m.lastSample
 	m.lastSample = metric
 
-	return Metrics{previousSample: oldLastSample.totals, currentSample: metric.totals, count: len(metric.list), isTotals: true}, nil
+	return Metrics{
+		previousSample: oldLastSample.totals,
+		currentSample:  metric.totals,
+		count:          len(metric.list),
+		isTotals:       true,
+		pressure:       metric.Pressure,
+	}, nil
 }
 
 // FetchCores collects a new sample of CPU usage metrics per-core
@@ -144,6 +209,10 @@ type Metrics struct {
 	count          int
 	cpuInfo        CPUInfo
 	isTotals       bool
+	// pressure carries PSI data for the host or cgroup as a whole. It is
+	// only ever set on totals samples (Fetch, FetchHistogram), since PSI
+	// itself is not broken down per-core.
+	pressure pressure.CPUPressure
 }
 
 // Format returns the final MapStr data object for the metrics.
@@ -183,19 +252,49 @@ func (metric Metrics) Format(opts MetricOpts) (mapstr.M, error) {
 	reportOptMetric("softirq", metric.currentSample.SoftIrq, metric.previousSample.SoftIrq, normCPU)
 	reportOptMetric("steal", metric.currentSample.Stolen, metric.previousSample.Stolen, normCPU)
 
+	if !metric.currentSample.ThrottledPeriods.IsZero() {
+		_, _ = formattedMetrics.Put("throttled.periods", metric.currentSample.ThrottledPeriods.ValueOr(0))
+	}
+	if !metric.currentSample.ThrottledTimeNs.IsZero() {
+		_, _ = formattedMetrics.Put("throttled.time.ns", metric.currentSample.ThrottledTimeNs.ValueOr(0))
+	}
+
+	if opts.Pressure && metric.isTotals && metric.pressure != (pressure.CPUPressure{}) {
+		_, _ = formattedMetrics.Put("pressure.cpu.some.avg10", metric.pressure.Some.Avg10)
+		_, _ = formattedMetrics.Put("pressure.cpu.some.avg60", metric.pressure.Some.Avg60)
+		_, _ = formattedMetrics.Put("pressure.cpu.some.avg300", metric.pressure.Some.Avg300)
+		_, _ = formattedMetrics.Put("pressure.cpu.some.total", metric.pressure.Some.Total)
+	}
+
 	// Only add CPU info metrics if we're returning information by core
 	// (isTotals is false)
 	if !metric.isTotals {
 		// Some platforms do not report those metrics, so metric.cpuInfo
 		// is empty, if that happens we do not add the empty metrics to the
 		// final event.
-		if metric.cpuInfo != (CPUInfo{}) {
+		if !metric.cpuInfo.IsZero() {
 			// /proc/cpuinfo metrics
 			formattedMetrics["model_number"] = metric.cpuInfo.ModelNumber
 			formattedMetrics["model_name"] = metric.cpuInfo.ModelName
 			formattedMetrics["mhz"] = metric.cpuInfo.Mhz
 			formattedMetrics["core_id"] = metric.cpuInfo.CoreID
 			formattedMetrics["physical_id"] = metric.cpuInfo.PhysicalID
+
+			if metric.cpuInfo.CurMhz != 0 {
+				_, _ = formattedMetrics.Put("frequency.cur.mhz", metric.cpuInfo.CurMhz)
+			}
+			if metric.cpuInfo.MinMhz != 0 {
+				_, _ = formattedMetrics.Put("frequency.min.mhz", metric.cpuInfo.MinMhz)
+			}
+			if metric.cpuInfo.MaxMhz != 0 {
+				_, _ = formattedMetrics.Put("frequency.max.mhz", metric.cpuInfo.MaxMhz)
+			}
+			if metric.cpuInfo.Governor != "" {
+				_, _ = formattedMetrics.Put("frequency.governor", metric.cpuInfo.Governor)
+			}
+			for state, ticks := range metric.cpuInfo.CStates {
+				_, _ = formattedMetrics.Put("cstate."+state, ticks)
+			}
 		}
 	}
 
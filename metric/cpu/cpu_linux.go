@@ -0,0 +1,283 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package cpu
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/opt"
+	"github.com/elastic/elastic-agent-system-metrics/metric/system/pressure"
+)
+
+const procStatPath = "/proc/stat"
+
+// Get collects a CPUMetrics sample for m. By default it reads the
+// host-wide /proc/stat; if m was configured with WithCgroupV2Scope, CPU
+// accounting is scoped to that cgroup's cpu.stat instead, normalized
+// against the vCPU count implied by cpu.max/cpuset.cpus.effective rather
+// than the host's. Either way, the totals are enriched with
+// /proc/pressure/cpu so MetricOpts.Pressure has something to report.
+func Get(m *Monitor) (CPUMetrics, error) {
+	var (
+		metrics CPUMetrics
+		err     error
+	)
+	if m.options.cgroupV2Path != "" {
+		metrics, err = getCgroupV2(m.options.cgroupV2Path)
+	} else {
+		metrics, err = getProcStat()
+	}
+	if err != nil {
+		return CPUMetrics{}, err
+	}
+
+	// PSI isn't available on every kernel (CONFIG_PSI, or cgroup v1
+	// hosts); GetCPU already returns a zero-value CPUPressure rather than
+	// an error in that case, so there's nothing to special-case here.
+	if p, err := pressure.GetCPU(); err == nil {
+		metrics.Pressure = p
+	}
+
+	if info, err := getCPUInfo(); err == nil {
+		metrics.CPUInfo = info
+	}
+
+	return metrics, nil
+}
+
+// getProcStat reads /proc/stat and returns the host-wide totals plus the
+// per-core breakdown.
+func getProcStat() (CPUMetrics, error) {
+	f, err := os.Open(procStatPath)
+	if err != nil {
+		return CPUMetrics{}, fmt.Errorf("error opening %s: %w", procStatPath, err)
+	}
+	defer f.Close()
+
+	var metrics CPUMetrics
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		sample := parseProcStatFields(fields[1:])
+		if fields[0] == "cpu" {
+			metrics.totals = sample
+			continue
+		}
+		metrics.list = append(metrics.list, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return CPUMetrics{}, fmt.Errorf("error reading %s: %w", procStatPath, err)
+	}
+	return metrics, nil
+}
+
+// parseProcStatFields parses the tick counters that follow a "cpu"/"cpuN"
+// label in /proc/stat, in the kernel's fixed column order.
+func parseProcStatFields(fields []string) CPU {
+	get := func(i int) opt.Uint {
+		if i >= len(fields) {
+			return opt.Uint{}
+		}
+		v, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return opt.Uint{}
+		}
+		return opt.UintWith(v)
+	}
+	return CPU{
+		User:    get(0),
+		Nice:    get(1),
+		Sys:     get(2),
+		Idle:    get(3),
+		Wait:    get(4),
+		Irq:     get(5),
+		SoftIrq: get(6),
+		Stolen:  get(7),
+	}
+}
+
+// getCgroupV2 reads CPU accounting from path's cpu.stat, instead of the
+// host-wide /proc/stat, and sizes the normalized percentage to the
+// cgroup's effective CPU count rather than runtime.NumCPU(), which
+// overreports inside containers with a fractional or partial CPU quota.
+func getCgroupV2(path string) (CPUMetrics, error) {
+	count, err := readCgroupCPUCount(path)
+	if err != nil {
+		return CPUMetrics{}, err
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	stat, err := readCgroupCPUStat(filepath.Join(path, "cpu.stat"), count)
+	if err != nil {
+		return CPUMetrics{}, err
+	}
+
+	// cgroup v2 doesn't break usage down per logical CPU the way
+	// /proc/stat does; synthesize a list sized to the cgroup's effective
+	// CPU count so normalization (and CPUCount) still works as expected.
+	metrics := CPUMetrics{totals: stat, list: make([]CPU, count)}
+	for i := range metrics.list {
+		metrics.list[i] = stat
+	}
+	return metrics, nil
+}
+
+// readCgroupCPUStat parses a cgroup v2 cpu.stat file into a CPU sample.
+// usage_usec/user_usec/system_usec are in microseconds, so they're
+// converted to the 10ms ticks the rest of this package assumes /proc/stat
+// reports.
+//
+// cpu.stat has no idle counter of its own (it only tracks usage), but
+// Format derives total.pct/total.norm.pct entirely from the delta of the
+// Idle field, so leaving it unset makes every cgroup-scoped sample look
+// like the cgroup spent all of count's available capacity and none of it
+// idle. Idle is therefore synthesized from wall-clock time: count's
+// available CPU-usec since the Unix epoch, minus usage_usec. Both halves
+// of that are monotonically increasing, so the delta Format actually
+// reads out (idle2-idle1) still correctly works out to (elapsed wall
+// time * count) - (usage delta) over the sampling interval, without cpu.stat
+// needing to report idle time directly.
+func readCgroupCPUStat(path string, count int) (CPU, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return CPU{}, fmt.Errorf("error opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	const usecPerTick = 10000
+
+	values := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[fields[0]] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return CPU{}, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	availableUsec := uint64(time.Now().UnixMicro()) * uint64(count)
+	usageUsec := values["usage_usec"]
+	var idleUsec uint64
+	if availableUsec > usageUsec {
+		idleUsec = availableUsec - usageUsec
+	}
+
+	return CPU{
+		User:             opt.UintWith(values["user_usec"] / usecPerTick),
+		Sys:              opt.UintWith(values["system_usec"] / usecPerTick),
+		Idle:             opt.UintWith(idleUsec / usecPerTick),
+		ThrottledPeriods: opt.UintWith(values["nr_throttled"]),
+		ThrottledTimeNs:  opt.UintWith(values["throttled_usec"] * 1000),
+	}, nil
+}
+
+// readCgroupCPUCount derives the number of vCPUs visible to the cgroup
+// from cpu.max's quota/period when a quota is set, falling back to the
+// cardinality of cpuset.cpus.effective.
+func readCgroupCPUCount(path string) (int, error) {
+	if count, err := readCPUMaxCount(filepath.Join(path, "cpu.max")); err == nil {
+		return count, nil
+	}
+	return readCPUSetCount(filepath.Join(path, "cpuset.cpus.effective"))
+}
+
+// readCPUMaxCount reads a cgroup v2 cpu.max file ("$quota $period", or
+// "max $period" when unlimited) and returns ceil(quota/period).
+func readCPUMaxCount(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0, fmt.Errorf("no quota set in %s", path)
+	}
+	quota, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	period, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || period == 0 {
+		return 0, fmt.Errorf("invalid period in %s", path)
+	}
+	count := int(quota/period + 0.999999)
+	if count < 1 {
+		count = 1
+	}
+	return count, nil
+}
+
+// readCPUSetCount reads a cgroup v2 cpuset.cpus.effective file and counts
+// the CPUs it describes.
+func readCPUSetCount(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return countCPUSetList(strings.TrimSpace(string(data)))
+}
+
+// countCPUSetList counts the CPUs described by a cpuset list such as
+// "0-3,7,9-11".
+func countCPUSetList(list string) (int, error) {
+	if list == "" {
+		return 0, fmt.Errorf("empty cpuset list")
+	}
+	count := 0
+	for _, part := range strings.Split(list, ",") {
+		if part == "" {
+			continue
+		}
+		lo, hi, ok := strings.Cut(part, "-")
+		if !ok {
+			count++
+			continue
+		}
+		loN, err := strconv.Atoi(lo)
+		if err != nil {
+			return 0, err
+		}
+		hiN, err := strconv.Atoi(hi)
+		if err != nil {
+			return 0, err
+		}
+		count += hiN - loN + 1
+	}
+	return count, nil
+}
@@ -0,0 +1,110 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package cpu
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCountCPUSetList(t *testing.T) {
+	tests := map[string]struct {
+		list string
+		want int
+	}{
+		"single range":   {"0-3", 4},
+		"single cpu":     {"7", 1},
+		"mixed":          {"0-3,7,9-11", 8},
+		"one cpu":        {"0", 1},
+		"trailing comma": {"0-1,", 2},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := countCPUSetList(tc.list)
+			if err != nil {
+				t.Fatalf("countCPUSetList(%q) returned error: %v", tc.list, err)
+			}
+			if got != tc.want {
+				t.Fatalf("countCPUSetList(%q) = %d, want %d", tc.list, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCountCPUSetListEmpty(t *testing.T) {
+	if _, err := countCPUSetList(""); err == nil {
+		t.Fatal("countCPUSetList(\"\") should return an error")
+	}
+}
+
+// TestCgroupV2ReportsLessThanFullyBusy is a regression test for a cgroup
+// v2 scoped Monitor reporting ~100% busy on every call regardless of
+// actual usage, because cpu.stat has no idle counter and Idle was simply
+// left unset.
+func TestCgroupV2ReportsLessThanFullyBusy(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "cpu.max"), "200000 100000") // 2 vCPU quota
+
+	writeCPUStat := func(usageUsec, userUsec, systemUsec uint64) {
+		content := fmt.Sprintf(
+			"usage_usec %d\nuser_usec %d\nsystem_usec %d\nnr_periods 0\nnr_throttled 0\nthrottled_usec 0\n",
+			usageUsec, userUsec, systemUsec,
+		)
+		writeFile(t, filepath.Join(root, "cpu.stat"), content)
+	}
+
+	m := New(WithCgroupV2Scope(root))
+
+	writeCPUStat(0, 0, 0)
+	if _, err := m.Fetch(); err != nil {
+		t.Fatalf("first Fetch: %v", err)
+	}
+
+	// Sleep long enough that the idle time synthesized from wall-clock
+	// time dwarfs the tiny amount of usage added below; before the fix,
+	// cpu.stat-scoped samples always reported total.norm.pct == 1
+	// regardless of how much wall-clock time (or how little usage)
+	// actually passed.
+	time.Sleep(100 * time.Millisecond)
+	writeCPUStat(20000, 12000, 8000)
+
+	sample, err := m.Fetch()
+	if err != nil {
+		t.Fatalf("second Fetch: %v", err)
+	}
+	formatted, err := sample.Format(MetricOpts{NormalizedPercentages: true})
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	v, err := formatted.GetValue("total.norm.pct")
+	if err != nil {
+		t.Fatalf("GetValue(total.norm.pct): %v", err)
+	}
+	pct, ok := v.(float64)
+	if !ok {
+		t.Fatalf("total.norm.pct is %T, want float64", v)
+	}
+	if pct >= 0.9 {
+		t.Fatalf("total.norm.pct = %v, want well under 1 for a cgroup that was mostly idle", pct)
+	}
+}
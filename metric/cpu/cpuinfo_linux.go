@@ -0,0 +1,170 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package cpu
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	procCPUInfoPath  = "/proc/cpuinfo"
+	sysCPUDeviceGlob = "/sys/devices/system/cpu"
+)
+
+// getCPUInfo reads /proc/cpuinfo for the static, per-core fields and
+// enriches each entry with the live cpufreq and cpuidle data found under
+// /sys/devices/system/cpu/cpuN.
+func getCPUInfo() ([]CPUInfo, error) {
+	infos, err := readProcCPUInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range infos {
+		addFrequencyInfo(sysCPUDeviceGlob, i, &infos[i])
+		addCStateInfo(sysCPUDeviceGlob, i, &infos[i])
+	}
+	return infos, nil
+}
+
+// readProcCPUInfo parses /proc/cpuinfo, which lists one blank-line
+// separated stanza of "key : value" pairs per logical CPU, in CPU order.
+func readProcCPUInfo() ([]CPUInfo, error) {
+	f, err := os.Open(procCPUInfoPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %w", procCPUInfoPath, err)
+	}
+	defer f.Close()
+
+	var infos []CPUInfo
+	cur := CPUInfo{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			infos = append(infos, cur)
+			cur = CPUInfo{}
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch key {
+		case "model name":
+			cur.ModelName = value
+		case "model":
+			cur.ModelNumber = value
+		case "cpu MHz":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				cur.Mhz = v
+			}
+		case "physical id":
+			if v, err := strconv.Atoi(value); err == nil {
+				cur.PhysicalID = v
+			}
+		case "core id":
+			if v, err := strconv.Atoi(value); err == nil {
+				cur.CoreID = v
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", procCPUInfoPath, err)
+	}
+	return infos, nil
+}
+
+// addFrequencyInfo fills in the live scaling frequency and governor for
+// logical CPU cpuN from sysRoot/cpuN/cpufreq.
+func addFrequencyInfo(sysRoot string, cpuN int, info *CPUInfo) {
+	base := filepath.Join(sysRoot, fmt.Sprintf("cpu%d", cpuN), "cpufreq")
+
+	info.CurMhz = readSysFreqMhz(filepath.Join(base, "scaling_cur_freq"))
+	if info.CurMhz == 0 {
+		// scaling_cur_freq requires the cpufreq driver to track it;
+		// cpuinfo_cur_freq comes from a hardware counter on drivers
+		// (e.g. intel_pstate) that don't.
+		info.CurMhz = readSysFreqMhz(filepath.Join(base, "cpuinfo_cur_freq"))
+	}
+	info.MinMhz = readSysFreqMhz(filepath.Join(base, "scaling_min_freq"))
+	info.MaxMhz = readSysFreqMhz(filepath.Join(base, "scaling_max_freq"))
+
+	if governor, err := os.ReadFile(filepath.Join(base, "scaling_governor")); err == nil {
+		info.Governor = strings.TrimSpace(string(governor))
+	}
+}
+
+// readSysFreqMhz reads a cpufreq sysfs file containing a frequency in KHz
+// and converts it to MHz. It returns 0 if the file is missing, which
+// happens when no cpufreq driver is loaded for this CPU.
+func readSysFreqMhz(path string) float64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	khz, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0
+	}
+	return khz / 1000
+}
+
+// addCStateInfo fills in cumulative C-state residency for logical CPU
+// cpuN from sysRoot/cpuN/cpuidle/state*/{name,time}.
+func addCStateInfo(sysRoot string, cpuN int, info *CPUInfo) {
+	base := filepath.Join(sysRoot, fmt.Sprintf("cpu%d", cpuN), "cpuidle")
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return
+	}
+
+	states := map[string]uint64{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "state") {
+			continue
+		}
+		stateDir := filepath.Join(base, entry.Name())
+
+		name, err := os.ReadFile(filepath.Join(stateDir, "name"))
+		if err != nil {
+			continue
+		}
+		residency, err := os.ReadFile(filepath.Join(stateDir, "time"))
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(string(residency)), 10, 64)
+		if err != nil {
+			continue
+		}
+		states[strings.TrimSpace(string(name))] = value
+	}
+	if len(states) > 0 {
+		info.CStates = states
+	}
+}
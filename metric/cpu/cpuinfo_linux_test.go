@@ -0,0 +1,100 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package cpu
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddFrequencyInfo(t *testing.T) {
+	root := t.TempDir()
+	cpufreqDir := filepath.Join(root, "cpu0", "cpufreq")
+	if err := os.MkdirAll(cpufreqDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(cpufreqDir, "scaling_cur_freq"), "2100000")
+	writeFile(t, filepath.Join(cpufreqDir, "scaling_min_freq"), "800000")
+	writeFile(t, filepath.Join(cpufreqDir, "scaling_max_freq"), "3700000")
+	writeFile(t, filepath.Join(cpufreqDir, "scaling_governor"), "powersave\n")
+
+	var info CPUInfo
+	addFrequencyInfo(root, 0, &info)
+
+	if info.CurMhz != 2100 || info.MinMhz != 800 || info.MaxMhz != 3700 {
+		t.Fatalf("unexpected frequencies: %+v", info)
+	}
+	if info.Governor != "powersave" {
+		t.Fatalf("unexpected governor: %q", info.Governor)
+	}
+}
+
+func TestAddFrequencyInfoFallsBackToCpuinfoCurFreq(t *testing.T) {
+	root := t.TempDir()
+	cpufreqDir := filepath.Join(root, "cpu0", "cpufreq")
+	if err := os.MkdirAll(cpufreqDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(cpufreqDir, "cpuinfo_cur_freq"), "1500000")
+
+	var info CPUInfo
+	addFrequencyInfo(root, 0, &info)
+
+	if info.CurMhz != 1500 {
+		t.Fatalf("expected CurMhz to fall back to cpuinfo_cur_freq, got %+v", info)
+	}
+}
+
+func TestAddCStateInfo(t *testing.T) {
+	root := t.TempDir()
+	state0 := filepath.Join(root, "cpu0", "cpuidle", "state0")
+	state1 := filepath.Join(root, "cpu0", "cpuidle", "state1")
+	if err := os.MkdirAll(state0, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(state1, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(state0, "name"), "POLL\n")
+	writeFile(t, filepath.Join(state0, "time"), "1000\n")
+	writeFile(t, filepath.Join(state1, "name"), "C1\n")
+	writeFile(t, filepath.Join(state1, "time"), "2000\n")
+
+	var info CPUInfo
+	addCStateInfo(root, 0, &info)
+
+	want := map[string]uint64{"POLL": 1000, "C1": 2000}
+	if len(info.CStates) != len(want) {
+		t.Fatalf("CStates = %+v, want %+v", info.CStates, want)
+	}
+	for k, v := range want {
+		if info.CStates[k] != v {
+			t.Fatalf("CStates[%q] = %d, want %d", k, info.CStates[k], v)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,266 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cpu
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// histogramBucketBounds are the (inclusive) upper bounds, in percent, of
+// the buckets tracked by Histogram. They're exponentially spaced up to 10%
+// (1, 2, 5, 10), giving low-utilization samples the finest resolution, and
+// 10 points wide from there up to 100%.
+var histogramBucketBounds = []float64{1, 2, 5, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+
+// HistogramBucket is a snapshot of one bucket tracked by a Histogram.
+type HistogramBucket struct {
+	// UpperBound is the inclusive upper bound, in percent, of this bucket.
+	UpperBound float64
+	// Count is the number of samples that landed in this bucket.
+	Count uint64
+}
+
+// Histogram is a rolling distribution of per-sample CPU utilization,
+// covering the trailing window passed to FetchHistogram, broken down into
+// a total-CPU tracker and one tracker per core. It lets callers ask for
+// p50/p95/p99-style CPU usage without having to post-process raw ticks
+// themselves.
+type Histogram struct {
+	window time.Duration
+
+	total *histogramTracker
+
+	mu    sync.Mutex
+	cores []*histogramTracker
+}
+
+// FetchHistogram collects a new CPU sample, both totals and per-core, and
+// folds it into a rolling histogram of utilization covering the trailing
+// window. Repeated calls reuse and age out the same histogram (stored on
+// m), so callers can poll on every interval and read back quantiles over
+// CPU spikes instead of just the last-interval average.
+//
+// Unlike calling Fetch and FetchCores separately, this reads the
+// underlying CPU source exactly once per call, so the totals and per-core
+// samples folded into the histogram are always diffed against the same
+// pair of snapshots instead of two independently-read ones.
+func (m *Monitor) FetchHistogram(window time.Duration) (*Histogram, error) {
+	if m.histogram == nil {
+		m.histogram = &Histogram{window: window, total: newHistogramTracker()}
+	}
+	h := m.histogram
+
+	current, err := Get(m)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching CPU metrics for histogram: %w", err)
+	}
+	previous := m.lastSample
+	m.lastSample = current
+
+	totalSample := Metrics{
+		previousSample: previous.totals,
+		currentSample:  current.totals,
+		count:          len(current.list),
+		isTotals:       true,
+		pressure:       current.Pressure,
+	}
+	if pct, ok := normPct(totalSample); ok {
+		h.total.add(pct, window)
+	}
+
+	h.mu.Lock()
+	for len(h.cores) < len(current.list) {
+		h.cores = append(h.cores, newHistogramTracker())
+	}
+	h.mu.Unlock()
+
+	for i := range current.list {
+		previousCore := CPU{}
+		if len(previous.list) > i {
+			previousCore = previous.list[i]
+		}
+		coreSample := Metrics{
+			previousSample: previousCore,
+			currentSample:  current.list[i],
+			isTotals:       false,
+		}
+		if len(current.CPUInfo) != 0 {
+			coreSample.cpuInfo = current.CPUInfo[i]
+		}
+		if pct, ok := normPct(coreSample); ok {
+			h.cores[i].add(pct, window)
+		}
+	}
+
+	return h, nil
+}
+
+// normPct extracts the normalized (0-1, relative to the capacity of the
+// CPUs the sample covers) CPU usage fraction from sample and converts it to
+// the 0-100 percent scale histogramBucketBounds is expressed in. It returns
+// false if sample has no previous sample to diff against yet.
+func normPct(sample Metrics) (float64, bool) {
+	if sample.previousSample == (CPU{}) {
+		return 0, false
+	}
+	formatted, err := sample.Format(MetricOpts{NormalizedPercentages: true})
+	if err != nil {
+		return 0, false
+	}
+	v, err := formatted.GetValue("total.norm.pct")
+	if err != nil {
+		return 0, false
+	}
+	fraction, ok := v.(float64)
+	if !ok {
+		return 0, false
+	}
+	return fraction * 100, true
+}
+
+// Buckets returns a snapshot of the current total-CPU bucket counts.
+func (h *Histogram) Buckets() []HistogramBucket {
+	return h.total.buckets()
+}
+
+// Quantile returns the upper bound of the bucket containing the p-th
+// quantile (0 <= p <= 1) of the tracked total-CPU distribution. It returns
+// 0 if no samples have been recorded yet.
+func (h *Histogram) Quantile(p float64) float64 {
+	return h.total.quantile(p)
+}
+
+// CoreBuckets returns a snapshot of the current bucket counts for the
+// given core index. It returns nil if core is beyond the cores seen by
+// FetchHistogram so far; a core that has been seen but has no samples yet
+// gets all-zero counts.
+func (h *Histogram) CoreBuckets(core int) []HistogramBucket {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if core < 0 || core >= len(h.cores) {
+		return nil
+	}
+	return h.cores[core].buckets()
+}
+
+// CoreQuantile returns the upper bound of the bucket containing the p-th
+// quantile (0 <= p <= 1) of the tracked distribution for the given core
+// index. It returns 0 if core has never reported a sample.
+func (h *Histogram) CoreQuantile(core int, p float64) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if core < 0 || core >= len(h.cores) {
+		return 0
+	}
+	return h.cores[core].quantile(p)
+}
+
+// histogramTracker holds the bucket counts and ring of timestamped entries
+// for a single series (either the totals or one core). Histogram keeps one
+// of these per series so the bucketing/eviction/quantile math isn't
+// duplicated between them.
+type histogramTracker struct {
+	mu      sync.Mutex
+	counts  []uint64
+	history []histogramEntry
+}
+
+// histogramEntry records which bucket a single sample fell into, so it can
+// be evicted again once it ages out of the window.
+type histogramEntry struct {
+	at     time.Time
+	bucket int
+}
+
+func newHistogramTracker() *histogramTracker {
+	return &histogramTracker{counts: make([]uint64, len(histogramBucketBounds))}
+}
+
+// add records pct (already on the same 0-100 scale as
+// histogramBucketBounds) and evicts any samples that have aged out of
+// window.
+func (t *histogramTracker) add(pct float64, window time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	bucket := bucketIndex(pct)
+	t.counts[bucket]++
+	t.history = append(t.history, histogramEntry{at: now, bucket: bucket})
+
+	cutoff := now.Add(-window)
+	evict := 0
+	for evict < len(t.history) && t.history[evict].at.Before(cutoff) {
+		t.counts[t.history[evict].bucket]--
+		evict++
+	}
+	t.history = t.history[evict:]
+}
+
+// bucketIndex returns the index into histogramBucketBounds (and Buckets)
+// that pct falls into.
+func bucketIndex(pct float64) int {
+	for i, bound := range histogramBucketBounds {
+		if pct <= bound {
+			return i
+		}
+	}
+	return len(histogramBucketBounds) - 1
+}
+
+func (t *histogramTracker) buckets() []HistogramBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]HistogramBucket, len(histogramBucketBounds))
+	for i, bound := range histogramBucketBounds {
+		out[i] = HistogramBucket{UpperBound: bound, Count: t.counts[i]}
+	}
+	return out
+}
+
+// quantile returns the upper bound of the first bucket whose cumulative
+// count reaches the p-th quantile, or 0 if no samples have been recorded
+// yet. It deliberately doesn't interpolate within that bucket: a
+// histogramTracker only knows how many samples fell into each bucket, not
+// where within the bucket's range they landed, so interpolating would
+// assume a uniform distribution this package has no basis for.
+func (t *histogramTracker) quantile(p float64) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var total uint64
+	for _, count := range t.counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	target := p * float64(total)
+	var cumulative uint64
+	for i, count := range t.counts {
+		cumulative += count
+		if float64(cumulative) >= target {
+			return histogramBucketBounds[i]
+		}
+	}
+	return histogramBucketBounds[len(histogramBucketBounds)-1]
+}
@@ -0,0 +1,133 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cpu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/opt"
+)
+
+func TestNormPctConvertsFractionToPercent(t *testing.T) {
+	sample := Metrics{
+		previousSample: CPU{User: opt.UintWith(0), Idle: opt.UintWith(0)},
+		currentSample:  CPU{User: opt.UintWith(50), Idle: opt.UintWith(50)},
+		count:          1,
+		isTotals:       true,
+	}
+
+	pct, ok := normPct(sample)
+	if !ok {
+		t.Fatal("normPct() reported no value, want a value")
+	}
+	// total.norm.pct is a 0-1 fraction; normPct must scale it to 0-100 to
+	// match histogramBucketBounds, which is expressed in percent.
+	if pct != 50 {
+		t.Fatalf("normPct() = %v, want 50 (percent, not a 0-1 fraction)", pct)
+	}
+}
+
+func TestNormPctNoPreviousSample(t *testing.T) {
+	sample := Metrics{count: 1, isTotals: true}
+	if _, ok := normPct(sample); ok {
+		t.Fatal("normPct() reported a value with no previous sample to diff against")
+	}
+}
+
+func TestHistogramTrackerAddAndBuckets(t *testing.T) {
+	tr := newHistogramTracker()
+	tr.add(5, time.Minute)
+	tr.add(5, time.Minute)
+	tr.add(75, time.Minute)
+
+	buckets := tr.buckets()
+	var got5, got80 uint64
+	for _, b := range buckets {
+		switch b.UpperBound {
+		case 5:
+			got5 = b.Count
+		case 80:
+			got80 = b.Count
+		}
+	}
+	if got5 != 2 {
+		t.Fatalf("bucket[5] count = %d, want 2", got5)
+	}
+	if got80 != 1 {
+		t.Fatalf("bucket[80] count = %d, want 1", got80)
+	}
+}
+
+func TestHistogramTrackerEvictsOldSamples(t *testing.T) {
+	tr := newHistogramTracker()
+	tr.history = append(tr.history, histogramEntry{at: time.Now().Add(-time.Hour), bucket: 0})
+	tr.counts[0]++
+
+	tr.add(5, time.Minute)
+
+	if len(tr.history) != 1 {
+		t.Fatalf("len(history) = %d, want 1 (the stale entry should have been evicted)", len(tr.history))
+	}
+	if tr.counts[0] != 0 {
+		t.Fatalf("counts[0] = %d, want 0 (the stale entry's bucket count should have been decremented)", tr.counts[0])
+	}
+}
+
+func TestHistogramTrackerQuantile(t *testing.T) {
+	tr := newHistogramTracker()
+	for _, pct := range []float64{1, 1, 1, 1, 100} {
+		tr.add(pct, time.Minute)
+	}
+
+	if q := tr.quantile(0.5); q != 1 {
+		t.Fatalf("quantile(0.5) = %v, want 1", q)
+	}
+	if q := tr.quantile(1); q != 100 {
+		t.Fatalf("quantile(1) = %v, want 100", q)
+	}
+}
+
+func TestHistogramTrackerQuantileEmpty(t *testing.T) {
+	tr := newHistogramTracker()
+	if q := tr.quantile(0.5); q != 0 {
+		t.Fatalf("quantile(0.5) on an empty tracker = %v, want 0", q)
+	}
+}
+
+func TestHistogramCoreBucketsAreIndependentOfTotals(t *testing.T) {
+	h := &Histogram{total: newHistogramTracker()}
+	h.total.add(5, time.Minute)
+
+	h.cores = []*histogramTracker{newHistogramTracker(), newHistogramTracker()}
+	h.cores[0].add(90, time.Minute)
+
+	if got := h.CoreQuantile(0, 1); got != 90 {
+		t.Fatalf("CoreQuantile(0, 1) = %v, want 90", got)
+	}
+	if got := h.Quantile(1); got != 5 {
+		t.Fatalf("Quantile(1) = %v, want 5 (should track totals, not core 0)", got)
+	}
+}
+
+func TestHistogramCoreBucketsOutOfRange(t *testing.T) {
+	h := &Histogram{total: newHistogramTracker()}
+	if got := h.CoreBuckets(0); got != nil {
+		t.Fatalf("CoreBuckets(0) on a Histogram with no cores = %+v, want nil", got)
+	}
+}
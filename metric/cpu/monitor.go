@@ -0,0 +1,41 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package cpu
+
+// Monitor samples CPU metrics, keeping the last sample around so Fetch and
+// FetchCores can report a delta against it. Use New to create one.
+type Monitor struct {
+	options option
+
+	lastSample CPUMetrics
+
+	// histogram backs FetchHistogram. It lives on the Monitor itself, not a
+	// package-level registry, so it's freed along with the Monitor instead
+	// of leaking a *Histogram (and the Monitor it keeps alive) for every
+	// distinct Monitor that ever called FetchHistogram.
+	histogram *Histogram
+}
+
+// New returns a Monitor configured with the given options.
+func New(opts ...OptionFunc) *Monitor {
+	m := &Monitor{}
+	for _, opt := range opts {
+		opt(&m.options)
+	}
+	return m
+}
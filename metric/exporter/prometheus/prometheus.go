@@ -0,0 +1,205 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package prometheus exposes the CPU, per-core, and (where a
+// ProcessSource is supplied) per-process metrics collected elsewhere in
+// this module as an http.Handler in the Prometheus text exposition
+// format. It writes that format by hand rather than depending on
+// github.com/prometheus/client_golang, so this package doesn't add a
+// dependency the rest of the module doesn't already carry. It lets
+// elastic-agent-system-metrics run standalone as a node-exporter-style
+// sidecar, rather than only being consumed from inside beats/agent.
+//
+// There is currently no cross-platform memory monitor in this module to
+// expose the same way; a MemorySource hook can be added here once one
+// exists.
+package prometheus
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/elastic/elastic-agent-system-metrics/metric/cpu"
+)
+
+// ProcessSample is the subset of a per-process snapshot Collector knows
+// how to render. metric/system/process.PssMonitor (Windows) and future
+// per-OS process monitors can be exposed through Collector by adapting
+// their own Fetch method into ProcessSource.
+type ProcessSample struct {
+	ThreadsCaptured uint32
+	ThreadsFailed   uint32
+}
+
+// ProcessSource is implemented by a per-process monitor that Collector can
+// scrape for a given PID.
+type ProcessSource interface {
+	Fetch(pid int) (ProcessSample, error)
+}
+
+// CollectorOption configures a Collector.
+type CollectorOption func(*Collector)
+
+// WithProcess adds per-process metrics for pids, sourced from source,
+// alongside the CPU metrics every Collector reports.
+func WithProcess(source ProcessSource, pids ...int) CollectorOption {
+	return func(c *Collector) {
+		c.process = source
+		c.pids = pids
+	}
+}
+
+// Collector is an http.Handler that scrapes a *cpu.Monitor (and,
+// optionally, a ProcessSource) on every request and renders the result in
+// the Prometheus text exposition format.
+type Collector struct {
+	monitor *cpu.Monitor
+
+	process ProcessSource
+	pids    []int
+}
+
+// NewCollector returns a Collector that reports CPU metrics gathered from
+// monitor, plus whatever CollectorOptions are given. Each request takes a
+// fresh sample, so the scrape interval becomes the effective metric
+// interval.
+func NewCollector(monitor *cpu.Monitor, opts ...CollectorOption) *Collector {
+	c := &Collector{monitor: monitor}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ServeHTTP implements http.Handler.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := c.write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (c *Collector) write(w io.Writer) error {
+	cores, err := c.monitor.FetchCores()
+	if err != nil {
+		return fmt.Errorf("error fetching per-core CPU metrics: %w", err)
+	}
+
+	opts := cpu.MetricOpts{Ticks: true, NormalizedPercentages: true}
+
+	writeHelp(w, "system_cpu_user_seconds_total", "counter", "Cumulative user CPU time, in seconds.")
+	writeHelp(w, "system_cpu_system_seconds_total", "counter", "Cumulative system CPU time, in seconds.")
+	writeHelp(w, "system_cpu_idle_seconds_total", "counter", "Cumulative idle CPU time, in seconds.")
+	writeHelp(w, "system_cpu_norm_pct", "gauge", "CPU usage as a fraction of a single core, normalized to 0-1.")
+	writeHelp(w, "system_cpu_core_info", "gauge", "Static per-core information; always 1.")
+
+	for i, core := range cores {
+		label := strconv.Itoa(i)
+
+		formatted, err := core.Format(opts)
+		if err != nil {
+			// Format fails when no ticks elapsed since the previous
+			// sample; skip this core rather than writing a partial line
+			// for it. The first scrape has no previous sample and is
+			// diffed against zero, so it reports usage since boot.
+			continue
+		}
+
+		if v, err := formatted.GetValue("user.ticks"); err == nil {
+			if ticks, ok := v.(uint64); ok {
+				writeMetric(w, "system_cpu_user_seconds_total", map[string]string{"cpu": label}, float64(ticks)/1000)
+			}
+		}
+		if v, err := formatted.GetValue("system.ticks"); err == nil {
+			if ticks, ok := v.(uint64); ok {
+				writeMetric(w, "system_cpu_system_seconds_total", map[string]string{"cpu": label}, float64(ticks)/1000)
+			}
+		}
+		if v, err := formatted.GetValue("idle.ticks"); err == nil {
+			if ticks, ok := v.(uint64); ok {
+				writeMetric(w, "system_cpu_idle_seconds_total", map[string]string{"cpu": label}, float64(ticks)/1000)
+			}
+		}
+		if v, err := formatted.GetValue("total.norm.pct"); err == nil {
+			if pct, ok := v.(float64); ok {
+				writeMetric(w, "system_cpu_norm_pct", map[string]string{"cpu": label}, pct)
+			}
+		}
+
+		coreID, _ := formatted.GetValue("core_id")
+		physicalID, _ := formatted.GetValue("physical_id")
+		modelName, _ := formatted.GetValue("model_name")
+		writeMetric(w, "system_cpu_core_info", map[string]string{
+			"cpu":         label,
+			"core_id":     fmt.Sprintf("%v", coreID),
+			"physical_id": fmt.Sprintf("%v", physicalID),
+			"model_name":  fmt.Sprintf("%v", modelName),
+		}, 1)
+	}
+
+	if c.process != nil {
+		writeHelp(w, "system_process_threads_captured", "gauge", "Threads captured in the last process snapshot.")
+		writeHelp(w, "system_process_threads_failed", "gauge", "Threads that failed to capture in the last process snapshot.")
+
+		for _, pid := range c.pids {
+			sample, err := c.process.Fetch(pid)
+			if err != nil {
+				// A process that exited between listing pids and
+				// scraping them isn't a scrape failure; just skip it.
+				continue
+			}
+			labels := map[string]string{"pid": strconv.Itoa(pid)}
+			writeMetric(w, "system_process_threads_captured", labels, float64(sample.ThreadsCaptured))
+			writeMetric(w, "system_process_threads_failed", labels, float64(sample.ThreadsFailed))
+		}
+	}
+
+	return nil
+}
+
+func writeHelp(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeMetric(w io.Writer, name string, labels map[string]string, value float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(labels), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// formatLabels renders labels in Prometheus's "{k=\"v\",...}" syntax,
+// sorted by key so output is stable across calls.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := "{"
+	for i, k := range keys {
+		if i > 0 {
+			out += ","
+		}
+		out += fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return out + "}"
+}
@@ -0,0 +1,51 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import "testing"
+
+func TestFormatLabels(t *testing.T) {
+	got := formatLabels(map[string]string{"cpu": "0", "model_name": "x86"})
+	want := `{cpu="0",model_name="x86"}`
+	if got != want {
+		t.Fatalf("formatLabels() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatLabelsEmpty(t *testing.T) {
+	if got := formatLabels(nil); got != "" {
+		t.Fatalf("formatLabels(nil) = %q, want empty string", got)
+	}
+}
+
+func TestWriteMetric(t *testing.T) {
+	var buf stringWriter
+	writeMetric(&buf, "system_cpu_norm_pct", map[string]string{"cpu": "1"}, 0.5)
+
+	want := "system_cpu_norm_pct{cpu=\"1\"} 0.5\n"
+	if string(buf) != want {
+		t.Fatalf("writeMetric() wrote %q, want %q", string(buf), want)
+	}
+}
+
+type stringWriter []byte
+
+func (s *stringWriter) Write(p []byte) (int, error) {
+	*s = append(*s, p...)
+	return len(p), nil
+}
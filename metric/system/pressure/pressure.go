@@ -0,0 +1,53 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package pressure parses Linux PSI (pressure stall information) from
+// /proc/pressure/{cpu,io,memory}. On platforms that don't expose PSI, the
+// Get* functions are no-ops returning a zero-value struct and a nil error,
+// so callers can treat pressure data as an optional enrichment instead of
+// special-casing every OS.
+package pressure
+
+// Avg holds one "some" or "full" line of a /proc/pressure/* file: the
+// rolling stall-time averages over the last 10, 60 and 300 seconds, plus
+// the cumulative stall time in microseconds since boot.
+type Avg struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  uint64
+}
+
+// CPUPressure carries the contents of /proc/pressure/cpu. CPU PSI only
+// ever reports a "some" line: by definition no task can be stalled on CPU
+// while every other task is also stalled, so there's no "full" state to
+// report.
+type CPUPressure struct {
+	Some Avg
+}
+
+// MemoryPressure carries the contents of /proc/pressure/memory.
+type MemoryPressure struct {
+	Some Avg
+	Full Avg
+}
+
+// IOPressure carries the contents of /proc/pressure/io.
+type IOPressure struct {
+	Some Avg
+	Full Avg
+}
@@ -0,0 +1,150 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package pressure
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	cpuPressurePath    = "/proc/pressure/cpu"
+	memoryPressurePath = "/proc/pressure/memory"
+	ioPressurePath     = "/proc/pressure/io"
+)
+
+// GetCPU reads and parses /proc/pressure/cpu.
+func GetCPU() (CPUPressure, error) {
+	lines, err := readPressureFile(cpuPressurePath)
+	if err != nil {
+		return CPUPressure{}, err
+	}
+	some, err := parseAvgLine(lines["some"])
+	if err != nil {
+		return CPUPressure{}, fmt.Errorf("error parsing %s: %w", cpuPressurePath, err)
+	}
+	return CPUPressure{Some: some}, nil
+}
+
+// GetMemory reads and parses /proc/pressure/memory.
+func GetMemory() (MemoryPressure, error) {
+	lines, err := readPressureFile(memoryPressurePath)
+	if err != nil {
+		return MemoryPressure{}, err
+	}
+	some, err := parseAvgLine(lines["some"])
+	if err != nil {
+		return MemoryPressure{}, fmt.Errorf("error parsing %s: %w", memoryPressurePath, err)
+	}
+	full, err := parseAvgLine(lines["full"])
+	if err != nil {
+		return MemoryPressure{}, fmt.Errorf("error parsing %s: %w", memoryPressurePath, err)
+	}
+	return MemoryPressure{Some: some, Full: full}, nil
+}
+
+// GetIO reads and parses /proc/pressure/io.
+func GetIO() (IOPressure, error) {
+	lines, err := readPressureFile(ioPressurePath)
+	if err != nil {
+		return IOPressure{}, err
+	}
+	some, err := parseAvgLine(lines["some"])
+	if err != nil {
+		return IOPressure{}, fmt.Errorf("error parsing %s: %w", ioPressurePath, err)
+	}
+	full, err := parseAvgLine(lines["full"])
+	if err != nil {
+		return IOPressure{}, fmt.Errorf("error parsing %s: %w", ioPressurePath, err)
+	}
+	return IOPressure{Some: some, Full: full}, nil
+}
+
+// readPressureFile reads a /proc/pressure/* file and returns its lines
+// keyed by their leading "some"/"full" token.
+func readPressureFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		// Kernels built without CONFIG_PSI, or cgroup v1 hosts, don't
+		// expose /proc/pressure at all; treat that as "no data" rather
+		// than failing every caller.
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		lines[fields[0]] = fields[1]
+	}
+	return lines, scanner.Err()
+}
+
+// parseAvgLine parses a line of the form
+// "avg10=0.00 avg60=0.00 avg300=0.00 total=0" into an Avg.
+func parseAvgLine(line string) (Avg, error) {
+	var avg Avg
+	if line == "" {
+		return avg, nil
+	}
+	for _, field := range strings.Fields(line) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "avg10":
+			v, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return Avg{}, err
+			}
+			avg.Avg10 = v
+		case "avg60":
+			v, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return Avg{}, err
+			}
+			avg.Avg60 = v
+		case "avg300":
+			v, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return Avg{}, err
+			}
+			avg.Avg300 = v
+		case "total":
+			v, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				return Avg{}, err
+			}
+			avg.Total = v
+		}
+	}
+	return avg, nil
+}
@@ -0,0 +1,50 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build linux
+
+package pressure
+
+import "testing"
+
+func TestParseAvgLine(t *testing.T) {
+	avg, err := parseAvgLine("avg10=0.50 avg60=1.25 avg300=2.00 total=123456")
+	if err != nil {
+		t.Fatalf("parseAvgLine returned error: %v", err)
+	}
+
+	want := Avg{Avg10: 0.50, Avg60: 1.25, Avg300: 2.00, Total: 123456}
+	if avg != want {
+		t.Fatalf("parseAvgLine() = %+v, want %+v", avg, want)
+	}
+}
+
+func TestParseAvgLineEmpty(t *testing.T) {
+	avg, err := parseAvgLine("")
+	if err != nil {
+		t.Fatalf("parseAvgLine(\"\") returned error: %v", err)
+	}
+	if avg != (Avg{}) {
+		t.Fatalf("parseAvgLine(\"\") = %+v, want zero value", avg)
+	}
+}
+
+func TestParseAvgLineMalformed(t *testing.T) {
+	if _, err := parseAvgLine("avg10=not-a-number avg60=1.25 avg300=2.00 total=1"); err == nil {
+		t.Fatal("parseAvgLine with a malformed field should return an error")
+	}
+}
@@ -0,0 +1,32 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build !linux
+
+package pressure
+
+// GetCPU always returns a zero-value CPUPressure: PSI is a Linux-only
+// kernel feature.
+func GetCPU() (CPUPressure, error) { return CPUPressure{}, nil }
+
+// GetMemory always returns a zero-value MemoryPressure: PSI is a
+// Linux-only kernel feature.
+func GetMemory() (MemoryPressure, error) { return MemoryPressure{}, nil }
+
+// GetIO always returns a zero-value IOPressure: PSI is a Linux-only kernel
+// feature.
+func GetIO() (IOPressure, error) { return IOPressure{}, nil }
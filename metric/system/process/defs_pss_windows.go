@@ -0,0 +1,102 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package process
+
+import "golang.org/x/sys/windows"
+
+// PSSCaptureFlags mirrors the PSS_CAPTURE_FLAGS enum consumed by
+// PssCaptureSnapshot to select what a process snapshot captures. Only the
+// subset used by PssMonitor is defined here.
+type PSSCaptureFlags uint32
+
+// Subset of PSS_CAPTURE_FLAGS needed to capture VA space, handles and
+// thread state in a single atomic pass.
+const (
+	PSSCaptureVaSpace PSSCaptureFlags = 0x00000800
+	PSSCaptureHandles PSSCaptureFlags = 0x00000004
+	PSSCaptureThreads PSSCaptureFlags = 0x00000080
+)
+
+// PSS_QUERY_INFORMATION_CLASS values that make PssQuerySnapshot return the
+// correspondingly named struct below.
+const (
+	pssQueryVaSpaceInformation = 3
+	pssQueryHandleInformation  = 4
+	pssQueryThreadInformation  = 5
+)
+
+// PSS_WALK_INFORMATION_CLASS values, in pssdk.h order. Only pssWalkThreads,
+// which makes PssWalkSnapshot enumerate PssThreadEntry entries, is used by
+// this package; the others are defined so a test can check pssWalkThreads
+// against the full, independently-ordered enum instead of a single
+// hardcoded literal.
+const (
+	pssWalkAuxiliaryPages     = 0
+	pssWalkVaCloneInformation = 1
+	pssWalkVaSpace            = 2
+	pssWalkHandles            = 3
+	pssWalkThreads            = 4
+)
+
+// PssThreadInformation mirrors PSS_THREAD_INFORMATION, returned by
+// PssQuerySnapshot when queried with PSS_QUERY_THREAD_INFORMATION.
+type PssThreadInformation struct {
+	ThreadsCaptured uint32
+	ThreadsFailed   uint32
+}
+
+// PssVaSpaceInformation mirrors PSS_VA_SPACE_INFORMATION, returned by
+// PssQuerySnapshot when queried with PSS_QUERY_VA_SPACE_INFORMATION.
+type PssVaSpaceInformation struct {
+	RegionCount uint32
+}
+
+// PssHandleInformation mirrors PSS_HANDLE_INFORMATION, returned by
+// PssQuerySnapshot when queried with PSS_QUERY_HANDLE_INFORMATION.
+type PssHandleInformation struct {
+	Count uint32
+}
+
+// PssClientID mirrors CLIENT_ID, identifying the process and thread a
+// PssThreadEntry describes.
+type PssClientID struct {
+	UniqueProcess uintptr
+	UniqueThread  uintptr
+}
+
+// PssThreadEntry mirrors PSS_THREAD_ENTRY, one entry returned per thread by
+// PssWalkSnapshot when walking a snapshot with pssWalkThreads. Only the
+// fields PssMonitor reads (KernelTime, UserTime) are relied upon; the rest
+// are kept so the struct's size and field offsets line up with pssdk.h.
+type PssThreadEntry struct {
+	ExitStatus               uint32
+	TebBaseAddress           uintptr
+	ClientID                 PssClientID
+	AffinityMask             uintptr
+	Priority                 int32
+	BasePriority             int32
+	LastSyscallFirstArgument uint32
+	LastSyscallNumber        uint16
+	_                        [2]byte
+	CreateTime               windows.Filetime
+	ExitTime                 windows.Filetime
+	KernelTime               windows.Filetime
+	UserTime                 windows.Filetime
+	Win32StartAddress        uintptr
+	WaitReason               uint32
+}
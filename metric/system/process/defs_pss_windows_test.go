@@ -0,0 +1,101 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package process
+
+import "testing"
+
+// TestPSSCaptureFlagValues guards against the capture flags silently
+// drifting out of sync with PSS_CAPTURE_FLAGS in pssdk.h, which would make
+// PssCaptureSnapshot capture the wrong data (or fail) without any build
+// error to catch it.
+func TestPSSCaptureFlagValues(t *testing.T) {
+	cases := map[string]struct {
+		got  PSSCaptureFlags
+		want PSSCaptureFlags
+	}{
+		"PSSCaptureVaSpace": {PSSCaptureVaSpace, 0x00000800},
+		"PSSCaptureHandles": {PSSCaptureHandles, 0x00000004},
+		"PSSCaptureThreads": {PSSCaptureThreads, 0x00000080},
+	}
+	for name, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s = %#x, want %#x", name, c.got, c.want)
+		}
+	}
+}
+
+// TestPSSQueryInformationClassValues guards against the
+// PSS_QUERY_INFORMATION_CLASS values drifting out of sync with pssdk.h,
+// which would make PssQuerySnapshot decode the wrong struct out of its
+// reply buffer.
+func TestPSSQueryInformationClassValues(t *testing.T) {
+	cases := map[string]struct {
+		got  int
+		want int
+	}{
+		"pssQueryVaSpaceInformation": {pssQueryVaSpaceInformation, 3},
+		"pssQueryHandleInformation":  {pssQueryHandleInformation, 4},
+		"pssQueryThreadInformation":  {pssQueryThreadInformation, 5},
+	}
+	for name, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s = %d, want %d", name, c.got, c.want)
+		}
+	}
+}
+
+// TestPSSCaptureFlagsDoNotOverlap checks that the capture flags this
+// package ORs together (PssMonitor.Fetch) don't share any bits, since
+// PSS_CAPTURE_FLAGS is a bitmask: an accidental overlap would mean one
+// flag silently also requests (part of) another capture kind.
+func TestPSSCaptureFlagsDoNotOverlap(t *testing.T) {
+	flags := map[string]PSSCaptureFlags{
+		"PSSCaptureVaSpace": PSSCaptureVaSpace,
+		"PSSCaptureHandles": PSSCaptureHandles,
+		"PSSCaptureThreads": PSSCaptureThreads,
+	}
+	seen := PSSCaptureFlags(0)
+	for name, flag := range flags {
+		if seen&flag != 0 {
+			t.Errorf("%s (%#x) overlaps a previously checked flag (%#x so far)", name, flag, seen)
+		}
+		seen |= flag
+	}
+}
+
+// TestPSSWalkInformationClassValues checks pssWalkThreads against the full
+// PSS_WALK_INFORMATION_CLASS ordering from pssdk.h (0..4), rather than a
+// single hardcoded literal next to it, so a future edit that shifts the
+// enum can't accidentally keep pssWalkThreads "correct" by coincidence.
+func TestPSSWalkInformationClassValues(t *testing.T) {
+	classes := []struct {
+		name string
+		got  int
+	}{
+		{"pssWalkAuxiliaryPages", pssWalkAuxiliaryPages},
+		{"pssWalkVaCloneInformation", pssWalkVaCloneInformation},
+		{"pssWalkVaSpace", pssWalkVaSpace},
+		{"pssWalkHandles", pssWalkHandles},
+		{"pssWalkThreads", pssWalkThreads},
+	}
+	for i, c := range classes {
+		if c.got != i {
+			t.Errorf("%s = %d, want %d", c.name, c.got, i)
+		}
+	}
+}
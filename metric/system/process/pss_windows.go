@@ -0,0 +1,142 @@
+// Licensed to Elasticsearch B.V. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. Elasticsearch B.V. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package process
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// ProcessSnapshot carries the subset of a PSS_CAPTURE_VA_SPACE |
+// PSS_CAPTURE_HANDLES | PSS_CAPTURE_THREADS snapshot that PssMonitor
+// queries for.
+type ProcessSnapshot struct {
+	ThreadsCaptured uint32
+	ThreadsFailed   uint32
+
+	// VaSpaceRegionCount is the number of virtual address space regions
+	// captured, from PSS_QUERY_VA_SPACE_INFORMATION.
+	VaSpaceRegionCount uint32
+
+	// HandleCount is the number of handles captured, from
+	// PSS_QUERY_HANDLE_INFORMATION.
+	HandleCount uint32
+
+	// ThreadKernelTime and ThreadUserTime are the summed kernel and user
+	// CPU time of every thread in the snapshot, walked via
+	// PssWalkSnapshot.
+	ThreadKernelTime time.Duration
+	ThreadUserTime   time.Duration
+}
+
+// PssMonitor collects per-process metrics via the Process Snapshotting API
+// (PssCaptureSnapshot/PssQuerySnapshot) instead of the series of
+// NtQueryInformationProcess calls used elsewhere in this package. Because
+// the snapshot is captured atomically, short-lived processes that exit
+// mid-scrape no longer produce "process exited between calls" errors.
+type PssMonitor struct{}
+
+// NewPssMonitor returns a PssMonitor ready to snapshot processes.
+func NewPssMonitor() *PssMonitor {
+	return &PssMonitor{}
+}
+
+// Fetch opens pid, captures a PSS_CAPTURE_VA_SPACE | PSS_CAPTURE_HANDLES |
+// PSS_CAPTURE_THREADS snapshot of it in one atomic pass, queries the
+// snapshot for thread information, and frees it before returning.
+func (PssMonitor) Fetch(pid int) (ProcessSnapshot, error) {
+	processHandle, err := windows.OpenProcess(windows.PROCESS_VM_READ|windows.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return ProcessSnapshot{}, fmt.Errorf("error opening process %d: %w", pid, err)
+	}
+	defer windows.CloseHandle(processHandle)
+
+	captureFlags := PSSCaptureVaSpace | PSSCaptureHandles | PSSCaptureThreads
+
+	var snapshotHandle syscall.Handle
+	if err := PssCaptureSnapshot(syscall.Handle(processHandle), captureFlags, 0, &snapshotHandle); err != nil {
+		return ProcessSnapshot{}, fmt.Errorf("error capturing snapshot of process %d: %w", pid, err)
+	}
+	defer func() {
+		_ = PssFreeSnapshot(syscall.Handle(processHandle), snapshotHandle)
+	}()
+
+	var threadInfo PssThreadInformation
+	if err := PssQuerySnapshot(snapshotHandle, pssQueryThreadInformation, unsafe.Pointer(&threadInfo), uint32(unsafe.Sizeof(threadInfo))); err != nil {
+		return ProcessSnapshot{}, fmt.Errorf("error querying thread information for process %d: %w", pid, err)
+	}
+
+	var vaSpaceInfo PssVaSpaceInformation
+	if err := PssQuerySnapshot(snapshotHandle, pssQueryVaSpaceInformation, unsafe.Pointer(&vaSpaceInfo), uint32(unsafe.Sizeof(vaSpaceInfo))); err != nil {
+		return ProcessSnapshot{}, fmt.Errorf("error querying VA space information for process %d: %w", pid, err)
+	}
+
+	var handleInfo PssHandleInformation
+	if err := PssQuerySnapshot(snapshotHandle, pssQueryHandleInformation, unsafe.Pointer(&handleInfo), uint32(unsafe.Sizeof(handleInfo))); err != nil {
+		return ProcessSnapshot{}, fmt.Errorf("error querying handle information for process %d: %w", pid, err)
+	}
+
+	kernelTime, userTime, err := walkThreadTimes(snapshotHandle)
+	if err != nil {
+		return ProcessSnapshot{}, fmt.Errorf("error walking threads for process %d: %w", pid, err)
+	}
+
+	return ProcessSnapshot{
+		ThreadsCaptured:    threadInfo.ThreadsCaptured,
+		ThreadsFailed:      threadInfo.ThreadsFailed,
+		VaSpaceRegionCount: vaSpaceInfo.RegionCount,
+		HandleCount:        handleInfo.Count,
+		ThreadKernelTime:   kernelTime,
+		ThreadUserTime:     userTime,
+	}, nil
+}
+
+// walkThreadTimes walks every PssThreadEntry in snapshotHandle and sums
+// their kernel and user CPU time.
+func walkThreadTimes(snapshotHandle syscall.Handle) (kernelTime, userTime time.Duration, err error) {
+	var walkMarkerHandle syscall.Handle
+	if err := PssWalkMarkerCreate(&walkMarkerHandle); err != nil {
+		return 0, 0, fmt.Errorf("error creating walk marker: %w", err)
+	}
+	defer func() {
+		_ = PssWalkMarkerFree(walkMarkerHandle)
+	}()
+
+	var entry PssThreadEntry
+	for {
+		err := PssWalkSnapshot(snapshotHandle, pssWalkThreads, walkMarkerHandle, unsafe.Pointer(&entry), uint32(unsafe.Sizeof(entry)))
+		if err == windows.ERROR_NO_MORE_ITEMS {
+			return kernelTime, userTime, nil
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("error walking snapshot: %w", err)
+		}
+		kernelTime += filetimeToDuration(entry.KernelTime)
+		userTime += filetimeToDuration(entry.UserTime)
+	}
+}
+
+// filetimeToDuration converts a FILETIME, in 100ns intervals, to a
+// time.Duration.
+func filetimeToDuration(ft windows.Filetime) time.Duration {
+	return time.Duration((int64(ft.HighDateTime)<<32 | int64(ft.LowDateTime)) * 100)
+}
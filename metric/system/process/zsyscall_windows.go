@@ -55,8 +55,12 @@ func errnoErr(e syscall.Errno) error {
 var (
 	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
 
-	procPssCaptureSnapshot = modkernel32.NewProc("PssCaptureSnapshot")
-	procPssQuerySnapshot   = modkernel32.NewProc("PssQuerySnapshot")
+	procPssCaptureSnapshot  = modkernel32.NewProc("PssCaptureSnapshot")
+	procPssQuerySnapshot    = modkernel32.NewProc("PssQuerySnapshot")
+	procPssFreeSnapshot     = modkernel32.NewProc("PssFreeSnapshot")
+	procPssWalkMarkerCreate = modkernel32.NewProc("PssWalkMarkerCreate")
+	procPssWalkSnapshot     = modkernel32.NewProc("PssWalkSnapshot")
+	procPssWalkMarkerFree   = modkernel32.NewProc("PssWalkMarkerFree")
 )
 
 func PssCaptureSnapshot(processHandle syscall.Handle, captureFlags PSSCaptureFlags, threadContextFlags uint32, snapshotHandle *syscall.Handle) (err error) {
@@ -68,8 +72,44 @@ func PssCaptureSnapshot(processHandle syscall.Handle, captureFlags PSSCaptureFla
 	return nil
 }
 
-func PssQuerySnapshot(snapshotHandle syscall.Handle, informationClass uint32, buffer *PssThreadInformation, bufferLength uint32) (err error) {
-	_, _, e1 := syscall.Syscall6(procPssQuerySnapshot.Addr(), 4, uintptr(snapshotHandle), uintptr(informationClass), uintptr(unsafe.Pointer(buffer)), uintptr(bufferLength), 0, 0)
+func PssQuerySnapshot(snapshotHandle syscall.Handle, informationClass uint32, buffer unsafe.Pointer, bufferLength uint32) (err error) {
+	_, _, e1 := syscall.Syscall6(procPssQuerySnapshot.Addr(), 4, uintptr(snapshotHandle), uintptr(informationClass), uintptr(buffer), uintptr(bufferLength), 0, 0)
+
+	if e1 != windows.ERROR_SUCCESS {
+		return e1
+	}
+	return nil
+}
+
+func PssFreeSnapshot(processHandle syscall.Handle, snapshotHandle syscall.Handle) (err error) {
+	_, _, e1 := syscall.Syscall(procPssFreeSnapshot.Addr(), 2, uintptr(processHandle), uintptr(snapshotHandle), 0)
+
+	if e1 != windows.ERROR_SUCCESS {
+		return e1
+	}
+	return nil
+}
+
+func PssWalkMarkerCreate(walkMarkerHandle *syscall.Handle) (err error) {
+	_, _, e1 := syscall.Syscall(procPssWalkMarkerCreate.Addr(), 2, 0, uintptr(unsafe.Pointer(walkMarkerHandle)), 0)
+
+	if e1 != windows.ERROR_SUCCESS {
+		return e1
+	}
+	return nil
+}
+
+func PssWalkSnapshot(snapshotHandle syscall.Handle, informationClass uint32, walkMarkerHandle syscall.Handle, buffer unsafe.Pointer, bufferLength uint32) (err error) {
+	_, _, e1 := syscall.Syscall6(procPssWalkSnapshot.Addr(), 5, uintptr(snapshotHandle), uintptr(informationClass), uintptr(walkMarkerHandle), uintptr(buffer), uintptr(bufferLength), 0)
+
+	if e1 != windows.ERROR_SUCCESS {
+		return e1
+	}
+	return nil
+}
+
+func PssWalkMarkerFree(walkMarkerHandle syscall.Handle) (err error) {
+	_, _, e1 := syscall.Syscall(procPssWalkMarkerFree.Addr(), 1, uintptr(walkMarkerHandle), 0, 0)
 
 	if e1 != windows.ERROR_SUCCESS {
 		return e1